@@ -0,0 +1,63 @@
+package backend
+
+import "reflect"
+
+// Apply populates the exported fields of the struct pointed to by target
+// whose tags include `inject:""`, resolving each field's type through
+// GetInstance (honoring the parent-injector chain). A non-empty tag value,
+// e.g. `inject:"primary"`, resolves a named binding instead of a type. Any
+// transient instances resolved during Apply are released before Apply
+// returns; use ApplyWithReleaser if a field's dependency needs to outlive
+// the call.
+func (i *Injector) Apply(target interface{}) error {
+	release, err := i.ApplyWithReleaser(target)
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// ApplyWithReleaser is like Apply, but returns a release function instead of
+// calling it. The caller is responsible for calling it once the populated
+// fields are no longer needed.
+func (i *Injector) ApplyWithReleaser(target interface{}) (func(), error) {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, ErrNotAStructPointer
+	}
+	elem := v.Elem()
+	t := elem.Type()
+	var releasers []func()
+	release := func() {
+		for _, r := range releasers {
+			r()
+		}
+	}
+	for n := 0; n < t.NumField(); n++ {
+		field := t.Field(n)
+		tag, ok := field.Tag.Lookup("inject")
+		if !ok {
+			continue
+		}
+		if !field.IsExported() {
+			release()
+			return nil, ErrCannotInjectUnexportedField
+		}
+		var instance interface{}
+		var releaser func()
+		var err error
+		if tag == "" {
+			instance, releaser, err = i.GetInstance(field.Type)
+		} else {
+			instance, releaser, err = i.getNamedInstance(tag, field.Type)
+		}
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releasers = append(releasers, releaser)
+		elem.Field(n).Set(reflect.ValueOf(instance))
+	}
+	return release, nil
+}
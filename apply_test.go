@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type appliedTarget struct {
+	Name    string `inject:""`
+	Unknown int
+}
+
+func Test_that_Apply_populates_tagged_fields(t *testing.T) {
+	i := NewInjector()
+	err := i.AddSingleton("injected")
+	require.Nil(t, err)
+	target := &appliedTarget{}
+	err = i.Apply(target)
+	require.Nil(t, err)
+	require.Equal(t, "injected", target.Name)
+	require.Equal(t, 0, target.Unknown)
+}
+
+func Test_that_Apply_returns_error_for_non_pointer_target(t *testing.T) {
+	i := NewInjector()
+	err := i.Apply(appliedTarget{})
+	require.Equal(t, ErrNotAStructPointer, err)
+}
+
+func Test_that_Apply_returns_error_when_field_type_is_not_registered(t *testing.T) {
+	i := NewInjector()
+	target := &appliedTarget{}
+	err := i.Apply(target)
+	require.Equal(t, ErrTypeNotRegistered, err)
+}
+
+type namedTarget struct {
+	Primary string `inject:"primary"`
+}
+
+func Test_that_Apply_resolves_named_bindings(t *testing.T) {
+	i := NewInjector()
+	i.named["primary"] = "primary value"
+	target := &namedTarget{}
+	err := i.Apply(target)
+	require.Nil(t, err)
+	require.Equal(t, "primary value", target.Primary)
+}
+
+type unexportedTarget struct {
+	name string `inject:""`
+}
+
+func Test_that_Apply_returns_error_for_unexported_tagged_field(t *testing.T) {
+	i := NewInjector()
+	err := i.AddSingleton("injected")
+	require.Nil(t, err)
+	target := &unexportedTarget{}
+	err = i.Apply(target)
+	require.Equal(t, ErrCannotInjectUnexportedField, err)
+}
+
+func Test_that_ApplyWithReleaser_defers_releasing_transients(t *testing.T) {
+	i := NewInjector()
+	closed := false
+	err := i.AddTransient(func() *closableObject { return &closableObject{closed: &closed} })
+	require.Nil(t, err)
+	target := &struct {
+		Obj *closableObject `inject:""`
+	}{}
+	release, err := i.ApplyWithReleaser(target)
+	require.Nil(t, err)
+	require.False(t, closed)
+	release()
+	require.True(t, closed)
+}
@@ -0,0 +1,97 @@
+package backend
+
+import "reflect"
+
+// AddSingletonAs registers instance as a singleton bound to the interface
+// type pointed to by ifacePtr (typically a nil pointer, e.g. `(*io.Writer)(nil)`),
+// rather than instance's own concrete type. This lets a concrete type be
+// looked up by an interface it satisfies, and lets two different concrete
+// singletons each be bound to a different interface without colliding.
+func (i *Injector) AddSingletonAs(instance interface{}, ifacePtr interface{}) error {
+	ift, err := interfaceType(ifacePtr)
+	if err != nil {
+		return err
+	}
+	if !reflect.TypeOf(instance).Implements(ift) {
+		return ErrDoesNotImplementInterface
+	}
+	if i.typeIsRegistered(ift) {
+		return ErrTypeAlreadyRegistered
+	}
+	i.singletons[ift] = instance
+	return nil
+}
+
+// AddTransientAs registers factory as a transient bound to the interface
+// type pointed to by ifacePtr, rather than the factory's own return type.
+func (i *Injector) AddTransientAs(factory interface{}, ifacePtr interface{}) error {
+	f := reflect.TypeOf(factory)
+	if f == nil || f.Kind() != reflect.Func || f.NumOut() == 0 {
+		return ErrInvalidFactory
+	}
+	ift, err := interfaceType(ifacePtr)
+	if err != nil {
+		return err
+	}
+	if !f.Out(0).Implements(ift) {
+		return ErrDoesNotImplementInterface
+	}
+	if i.typeIsRegistered(ift) {
+		return ErrTypeAlreadyRegistered
+	}
+	i.transients[ift] = factory
+	return nil
+}
+
+// interfaceType validates that ifacePtr is a pointer to an interface type
+// (e.g. `(*io.Writer)(nil)`) and returns the pointed-to interface type.
+func interfaceType(ifacePtr interface{}) (reflect.Type, error) {
+	pt := reflect.TypeOf(ifacePtr)
+	if pt == nil || pt.Kind() != reflect.Ptr || pt.Elem().Kind() != reflect.Interface {
+		return nil, ErrNotAnInterfacePointer
+	}
+	return pt.Elem(), nil
+}
+
+// AddNamedSingleton registers instance under name. Unlike AddSingleton, the
+// binding is keyed on name rather than type, so multiple singletons of the
+// same concrete type can coexist as long as each has a distinct role name.
+func (i *Injector) AddNamedSingleton(name string, instance interface{}) error {
+	if _, ok := i.named[name]; ok {
+		return ErrNameAlreadyRegistered
+	}
+	i.named[name] = instance
+	return nil
+}
+
+// GetNamedInstance returns the instance registered under name, honoring the
+// parent-injector chain. It returns ErrNamedTypeMismatch if the named
+// instance isn't assignable to t.
+func (i *Injector) GetNamedInstance(name string, t reflect.Type) (interface{}, func(), error) {
+	return i.getNamedInstance(name, t)
+}
+
+// namedHint lets Invoke recover a named binding's target name and type from
+// a Named[T] value without needing to know T at compile time.
+type namedHint interface {
+	resolve(i *Injector) (interface{}, func(), error)
+}
+
+// Named is a hint that can be passed as an initial argument to Invoke in
+// place of a T value, causing Invoke to resolve it via GetNamedInstance(name)
+// instead of GetInstance(reflect.TypeOf(T)). Use it when two dependencies
+// share a concrete type (e.g. two *sql.DB) and a role name is needed to tell
+// them apart: `r.di.Invoke(handler, c, backend.NewNamed[*sql.DB]("reporting"))`.
+type Named[T any] struct {
+	name string
+}
+
+// NewNamed creates a Named[T] hint for the given name.
+func NewNamed[T any](name string) Named[T] {
+	return Named[T]{name: name}
+}
+
+func (n Named[T]) resolve(i *Injector) (interface{}, func(), error) {
+	var zero T
+	return i.GetNamedInstance(n.name, reflect.TypeOf(&zero).Elem())
+}
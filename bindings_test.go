@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet() string { return "hello" }
+
+type frenchGreeter struct{}
+
+func (frenchGreeter) Greet() string { return "bonjour" }
+
+func Test_that_AddSingletonAs_binds_a_concrete_type_to_an_interface(t *testing.T) {
+	i := NewInjector()
+	err := i.AddSingletonAs(englishGreeter{}, (*greeter)(nil))
+	require.Nil(t, err)
+	instance, _, err := i.GetInstance(reflect.TypeOf((*greeter)(nil)).Elem())
+	require.Nil(t, err)
+	require.Equal(t, "hello", instance.(greeter).Greet())
+}
+
+func Test_that_AddSingletonAs_returns_error_if_instance_does_not_implement_interface(t *testing.T) {
+	i := NewInjector()
+	err := i.AddSingletonAs("not a greeter", (*greeter)(nil))
+	require.Equal(t, ErrDoesNotImplementInterface, err)
+}
+
+func Test_that_AddSingletonAs_returns_error_if_ifacePtr_is_not_an_interface_pointer(t *testing.T) {
+	i := NewInjector()
+	err := i.AddSingletonAs(englishGreeter{}, englishGreeter{})
+	require.Equal(t, ErrNotAnInterfacePointer, err)
+}
+
+func Test_that_AddTransientAs_binds_a_factory_to_an_interface(t *testing.T) {
+	i := NewInjector()
+	err := i.AddTransientAs(func() frenchGreeter { return frenchGreeter{} }, (*greeter)(nil))
+	require.Nil(t, err)
+	instance, _, err := i.GetInstance(reflect.TypeOf((*greeter)(nil)).Elem())
+	require.Nil(t, err)
+	require.Equal(t, "bonjour", instance.(greeter).Greet())
+}
+
+func Test_that_AddNamedSingleton_returns_error_when_name_already_registered(t *testing.T) {
+	i := NewInjector()
+	err := i.AddNamedSingleton("primary", "a")
+	require.Nil(t, err)
+	err = i.AddNamedSingleton("primary", "b")
+	require.Equal(t, ErrNameAlreadyRegistered, err)
+}
+
+func Test_that_GetNamedInstance_returns_named_singleton(t *testing.T) {
+	i := NewInjector()
+	err := i.AddNamedSingleton("primary", "primary value")
+	require.Nil(t, err)
+	instance, _, err := i.GetNamedInstance("primary", reflect.TypeOf(""))
+	require.Nil(t, err)
+	require.Equal(t, "primary value", instance)
+}
+
+func Test_that_GetNamedInstance_returns_error_for_unregistered_name(t *testing.T) {
+	i := NewInjector()
+	_, _, err := i.GetNamedInstance("missing", reflect.TypeOf(""))
+	require.Equal(t, ErrNamedTypeNotRegistered, err)
+}
+
+func Test_that_GetNamedInstance_returns_error_for_type_mismatch(t *testing.T) {
+	i := NewInjector()
+	err := i.AddNamedSingleton("primary", "a string")
+	require.Nil(t, err)
+	_, _, err = i.GetNamedInstance("primary", reflect.TypeOf(42))
+	require.Equal(t, ErrNamedTypeMismatch, err)
+}
+
+func Test_that_GetNamedInstance_falls_back_to_parent(t *testing.T) {
+	parent := NewInjector()
+	err := parent.AddNamedSingleton("primary", "parent value")
+	require.Nil(t, err)
+	child := parent.NewChild()
+	instance, _, err := child.GetNamedInstance("primary", reflect.TypeOf(""))
+	require.Nil(t, err)
+	require.Equal(t, "parent value", instance)
+}
+
+func Test_that_Invoke_resolves_a_Named_hint(t *testing.T) {
+	i := NewInjector()
+	err := i.AddNamedSingleton("reporting", "reporting db")
+	require.Nil(t, err)
+	f := func(name string) string { return name }
+	result, err := i.Invoke(f, NewNamed[string]("reporting"))
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{"reporting db"}, result)
+}
+
+func Test_that_Invoke_returns_error_when_Named_hint_is_unregistered(t *testing.T) {
+	i := NewInjector()
+	f := func(name string) string { return name }
+	_, err := i.Invoke(f, NewNamed[string]("missing"))
+	require.Equal(t, ErrNamedTypeNotRegistered, err)
+}
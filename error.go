@@ -21,4 +21,48 @@ const (
 	// ErrNotInvokable is returned when a function passed to Invoke is not
 	// invokable.
 	ErrNotInvokable = Error("not invokable")
+	// ErrCyclicInjectorParent is returned when resolving a type would walk
+	// the same injector's parent chain more than once.
+	ErrCyclicInjectorParent = Error("cyclic injector parent")
+	// ErrNamedTypeNotRegistered is returned when no instance is registered
+	// under a requested name.
+	ErrNamedTypeNotRegistered = Error("named type not registered")
+	// ErrNamedTypeMismatch is returned when an instance registered under a
+	// name isn't assignable to the requested type.
+	ErrNamedTypeMismatch = Error("named type mismatch")
+	// ErrNotAStructPointer is returned when Apply is called with a target
+	// that isn't a pointer to a struct.
+	ErrNotAStructPointer = Error("target is not a pointer to a struct")
+	// ErrCannotInjectUnexportedField is returned when Apply finds an
+	// `inject` tag on an unexported field.
+	ErrCannotInjectUnexportedField = Error("cannot inject unexported field")
+	// ErrNotAnInterfacePointer is returned when AddSingletonAs or
+	// AddTransientAs is given something other than a nil pointer to an
+	// interface type, e.g. `(*io.Writer)(nil)`.
+	ErrNotAnInterfacePointer = Error("not a pointer to an interface type")
+	// ErrDoesNotImplementInterface is returned when AddSingletonAs or
+	// AddTransientAs is given an instance/factory that doesn't implement
+	// the target interface.
+	ErrDoesNotImplementInterface = Error("does not implement interface")
+	// ErrNameAlreadyRegistered is returned when AddNamedSingleton is called
+	// twice with the same name.
+	ErrNameAlreadyRegistered = Error("name already registered")
+	// ErrJWTMalformed is returned when a bearer token isn't a well-formed
+	// compact JWS.
+	ErrJWTMalformed = Error("malformed jwt")
+	// ErrJWTMissingKeyID is returned when a token's header has no kid, so
+	// no JWK can be matched to it.
+	ErrJWTMissingKeyID = Error("jwt missing key id")
+	// ErrJWTUnknownKeyID is returned when no JWK in the key set matches a
+	// token's kid, even after an out-of-band refresh.
+	ErrJWTUnknownKeyID = Error("jwt unknown key id")
+	// ErrJWTUnsupportedAlgorithm is returned when a token's alg header is
+	// not one JWTWithJWKS knows how to verify.
+	ErrJWTUnsupportedAlgorithm = Error("jwt unsupported algorithm")
+	// ErrJWTMissingAudience is returned when a token has no aud claim to
+	// check against the accepted audiences.
+	ErrJWTMissingAudience = Error("jwt missing audience")
+	// ErrJWTAudienceMismatch is returned when none of a token's audiences
+	// are in the accepted list.
+	ErrJWTAudienceMismatch = Error("jwt audience mismatch")
 )
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPError is an error that knows which HTTP status code it should be
+// reported as. A handler returning (T, error) whose error implements
+// HTTPError has that status written instead of the default 500.
+type HTTPError interface {
+	error
+	StatusCode() int
+}
+
+// HTTPResponse lets a handler returning (T, error) take over writing the
+// response itself -- a redirect, a file, a streamed body -- while still
+// participating in DI via Invoke. It's checked in place of JSON-encoding
+// T when T implements it.
+type HTTPResponse interface {
+	Render(w http.ResponseWriter) error
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// wrap adapts handler into a gin.HandlerFunc. Handlers written as
+// func(*gin.Context, deps...) behave as before: they write the response
+// themselves. Handlers written as func(*gin.Context, deps...) (T, error) (or
+// (HTTPResponse, error)) have their response written for them: a non-nil
+// error is JSON-encoded with its HTTPError.StatusCode() (500 by default),
+// and a successful T is rendered via HTTPResponse if it implements it,
+// JSON-encoded with 200, or answered with a bare 204 if it's nil.
+func (r *Router) wrap(handler interface{}) gin.HandlerFunc {
+	t := reflect.TypeOf(handler)
+	returnsResult := t != nil && t.Kind() == reflect.Func && t.NumOut() == 2 && t.Out(1).Implements(errorType)
+	return func(c *gin.Context) {
+		results, err := r.di.Scope(c).Invoke(handler, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !returnsResult {
+			return
+		}
+		writeResult(c, results[0], results[1])
+	}
+}
+
+// writeResult writes the response for a handler that returned (value, err).
+func writeResult(c *gin.Context, value, err interface{}) {
+	if err != nil {
+		e := err.(error)
+		status := http.StatusInternalServerError
+		if he, ok := e.(HTTPError); ok {
+			status = he.StatusCode()
+		}
+		c.JSON(status, gin.H{"error": e.Error()})
+		c.Abort()
+		return
+	}
+	if response, ok := value.(HTTPResponse); ok {
+		if err := response.Render(c.Writer); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+		}
+		return
+	}
+	if isNilValue(value) {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// isNilValue reports whether v is a nil interface, or a nilable value
+// (pointer, slice, map, etc.) holding a nil.
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type greeting struct {
+	Message string `json:"message"`
+}
+
+func newTestRouter(t *testing.T) (*Router, *httptest.Server) {
+	t.Helper()
+	g := gin.New()
+	di := NewInjector()
+	err := di.AddSingleton(g)
+	require.Nil(t, err)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+	server := httptest.NewServer(g)
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func Test_that_a_typed_handler_JSON_encodes_a_successful_result(t *testing.T) {
+	r, server := newTestRouter(t)
+	r.GET("/greeting", func(c *gin.Context) (greeting, error) {
+		return greeting{Message: "hi"}, nil
+	})
+
+	resp, err := server.Client().Get(server.URL + "/greeting")
+	require.Nil(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	var body greeting
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "hi", body.Message)
+}
+
+type notFoundError string
+
+func (e notFoundError) Error() string   { return string(e) }
+func (e notFoundError) StatusCode() int { return http.StatusNotFound }
+
+func Test_that_a_typed_handler_error_is_reported_with_its_HTTPError_status(t *testing.T) {
+	r, server := newTestRouter(t)
+	r.GET("/missing", func(c *gin.Context) (*greeting, error) {
+		return nil, notFoundError("no such greeting")
+	})
+
+	resp, err := server.Client().Get(server.URL + "/missing")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_that_a_typed_handler_returning_a_nil_result_answers_204(t *testing.T) {
+	r, server := newTestRouter(t)
+	r.GET("/nothing", func(c *gin.Context) (*greeting, error) {
+		return nil, nil
+	})
+
+	resp, err := server.Client().Get(server.URL + "/nothing")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+type textResponse string
+
+func (body textResponse) Render(w http.ResponseWriter) error {
+	w.WriteHeader(http.StatusTeapot)
+	_, err := w.Write([]byte(body))
+	return err
+}
+
+func Test_that_a_typed_handler_can_return_an_HTTPResponse(t *testing.T) {
+	r, server := newTestRouter(t)
+	r.GET("/teapot", func(c *gin.Context) (textResponse, error) {
+		return textResponse("short and stout"), nil
+	})
+
+	resp, err := server.Client().Get(server.URL + "/teapot")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusTeapot, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, "short and stout", string(body))
+}
+
+func Test_that_an_untyped_handler_still_writes_its_own_response(t *testing.T) {
+	r, server := newTestRouter(t)
+	r.GET("/legacy", func(c *gin.Context) {
+		c.String(200, "legacy")
+	})
+
+	resp, err := server.Client().Get(server.URL + "/legacy")
+	require.Nil(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, "legacy", string(body))
+}
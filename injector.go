@@ -8,12 +8,19 @@ import (
 
 // Injector is a dependency injecting function invoker.
 type Injector struct {
+	// parent is the injector to fall back to when a type is not registered
+	// locally. It is nil for the root injector.
+	parent *Injector
 	// singletons holds instances of the given types which will be directly
 	// returned when requested.
 	singletons map[reflect.Type]interface{}
 	// transients holds instances of the given types which will be created
 	// using the given factory function when requested.
 	transients map[reflect.Type]interface{}
+	// named holds instances registered under a role name rather than a
+	// type, for cases where a type alone isn't enough to disambiguate a
+	// binding.
+	named map[string]interface{}
 }
 
 // NewInjector creates a new Injector.
@@ -21,6 +28,22 @@ func NewInjector() *Injector {
 	return &Injector{
 		singletons: make(map[reflect.Type]interface{}),
 		transients: make(map[reflect.Type]interface{}),
+		named:      make(map[string]interface{}),
+	}
+}
+
+// NewChild creates a new Injector which falls back to this Injector when a
+// type is not registered locally. Singletons and transients registered on
+// the child shadow those of the parent without mutating it, which makes
+// NewChild useful for per-request scopes: register request-scoped
+// dependencies on the child and let everything else resolve from the
+// shared root.
+func (i *Injector) NewChild() *Injector {
+	return &Injector{
+		parent:     i,
+		singletons: make(map[reflect.Type]interface{}),
+		transients: make(map[reflect.Type]interface{}),
+		named:      make(map[string]interface{}),
 	}
 }
 
@@ -57,8 +80,17 @@ func (i *Injector) AddTransient(factory interface{}) error {
 
 // GetInstance returns an instance of the given type. It returns the instance,
 // a releaser function, and an error. The releaser function should be called
-// when the instance is no longer needed.
+// when the instance is no longer needed. If the type is not registered
+// locally, GetInstance walks up the chain of parent injectors before
+// returning ErrTypeNotRegistered.
 func (i *Injector) GetInstance(t reflect.Type) (interface{}, func(), error) {
+	return i.getInstance(t, nil)
+}
+
+// getInstance is the implementation of GetInstance. seen guards against
+// cycles in the parent chain; it is lazily allocated so the common
+// non-cyclic case pays no cost.
+func (i *Injector) getInstance(t reflect.Type, seen map[*Injector]bool) (interface{}, func(), error) {
 	if instance, ok := i.singletons[t]; ok {
 		return instance, func() {}, nil
 	}
@@ -72,13 +104,51 @@ func (i *Injector) GetInstance(t reflect.Type) (interface{}, func(), error) {
 		}
 		return i, func() {}, nil
 	}
+	if i.parent != nil {
+		if seen == nil {
+			seen = map[*Injector]bool{}
+		}
+		if seen[i] {
+			return nil, nil, ErrCyclicInjectorParent
+		}
+		seen[i] = true
+		return i.parent.getInstance(t, seen)
+	}
 	return nil, nil, ErrTypeNotRegistered
 }
 
+// getNamedInstance returns the instance registered under name, walking the
+// parent chain the same way GetInstance does. It returns ErrNamedTypeMismatch
+// if the named instance isn't assignable to t.
+func (i *Injector) getNamedInstance(name string, t reflect.Type) (interface{}, func(), error) {
+	var seen map[*Injector]bool
+	for inj := i; ; inj = inj.parent {
+		if instance, ok := inj.named[name]; ok {
+			if !reflect.TypeOf(instance).AssignableTo(t) {
+				return nil, nil, ErrNamedTypeMismatch
+			}
+			return instance, func() {}, nil
+		}
+		if inj.parent == nil {
+			return nil, nil, ErrNamedTypeNotRegistered
+		}
+		if seen == nil {
+			seen = map[*Injector]bool{}
+		}
+		if seen[inj] {
+			return nil, nil, ErrCyclicInjectorParent
+		}
+		seen[inj] = true
+	}
+}
+
 // Invoke calls the given function, passing the initial arguments and then
 // injecting instances for the remaining arguments. It returns the results of
 // the function and an error. Invoke handles collecting the releaser functions
-// and calling them after the function has been called.
+// and calling them after the function has been called. An initial argument
+// may be a Named[T] hint (see NewNamed) in place of a T value, in which case
+// Invoke resolves it through GetNamedInstance instead of passing it through
+// as-is.
 func (i *Injector) Invoke(fn interface{}, args ...interface{}) ([]interface{}, error) {
 	f := reflect.TypeOf(fn)
 	if f.Kind() != reflect.Func {
@@ -86,8 +156,17 @@ func (i *Injector) Invoke(fn interface{}, args ...interface{}) ([]interface{}, e
 	}
 	// Collect the initial arguments.
 	values := make([]reflect.Value, len(args))
-	for i, arg := range args {
-		values[i] = reflect.ValueOf(arg)
+	for idx, arg := range args {
+		if hint, ok := arg.(namedHint); ok {
+			instance, releaser, err := hint.resolve(i)
+			if err != nil {
+				return nil, err
+			}
+			defer releaser()
+			values[idx] = reflect.ValueOf(instance)
+			continue
+		}
+		values[idx] = reflect.ValueOf(arg)
 	}
 	// Collect the remaining arguments.
 	for j := len(args); j < f.NumIn(); j++ {
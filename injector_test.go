@@ -168,3 +168,45 @@ func Test_that_Invoke_returns_an_error_if_GetInstance_returns_an_error(t *testin
 	_, err := i.Invoke(f)
 	require.Equal(t, ErrTypeNotRegistered, err)
 }
+
+func Test_that_NewChild_falls_back_to_parent_for_unregistered_types(t *testing.T) {
+	parent := NewInjector()
+	err := parent.AddSingleton("parent value")
+	require.Nil(t, err)
+	child := parent.NewChild()
+	instance, _, err := child.GetInstance(reflect.TypeOf(""))
+	require.Nil(t, err)
+	require.Equal(t, "parent value", instance)
+}
+
+func Test_that_NewChild_singleton_shadows_parent_without_mutating_it(t *testing.T) {
+	parent := NewInjector()
+	err := parent.AddSingleton("parent value")
+	require.Nil(t, err)
+	child := parent.NewChild()
+	err = child.AddSingleton("child value")
+	require.Nil(t, err)
+
+	instance, _, err := child.GetInstance(reflect.TypeOf(""))
+	require.Nil(t, err)
+	require.Equal(t, "child value", instance)
+
+	instance, _, err = parent.GetInstance(reflect.TypeOf(""))
+	require.Nil(t, err)
+	require.Equal(t, "parent value", instance)
+}
+
+func Test_that_GetInstance_returns_error_for_unregistered_type_with_no_parent(t *testing.T) {
+	child := NewInjector().NewChild()
+	_, _, err := child.GetInstance(reflect.TypeOf(""))
+	require.Equal(t, ErrTypeNotRegistered, err)
+}
+
+func Test_that_GetInstance_detects_cyclic_parent_chain(t *testing.T) {
+	a := NewInjector()
+	b := a.NewChild()
+	b.parent = a
+	a.parent = b
+	_, _, err := a.GetInstance(reflect.TypeOf(""))
+	require.Equal(t, ErrCyclicInjectorParent, err)
+}
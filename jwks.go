@@ -0,0 +1,248 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// KeySet resolves a JSON Web Key by its key ID. A jwk.Set (from
+// lestrrat-go/jwx) satisfies KeySet directly, so a pre-fetched or
+// manually managed set can be passed to WithKeySet as-is.
+type KeySet interface {
+	LookupKeyID(kid string) (jwk.Key, bool)
+}
+
+// refreshableKeySet is implemented by KeySets that support an out-of-band
+// refresh when a token's kid isn't found, bounded by their own minimum
+// refresh interval.
+type refreshableKeySet interface {
+	refreshOnUnknownKeyID()
+}
+
+// JWTWithJWKS is a middleware that verifies RS256/ES256 JWTs by matching
+// each token's kid header to a JWK fetched from jwksURL. The key set is
+// fetched lazily and cached; pass WithKeySet to supply a pre-built KeySet
+// (e.g. one shared across middlewares, or backed by a static jwk.Set)
+// instead of having JWTWithJWKS fetch jwksURL itself.
+func JWTWithJWKS(issuer, audience, jwksURL string, opts ...JWTOption) gin.HandlerFunc {
+	o := newJWTOptions(audience, opts)
+	keySet := o.keySet
+	if keySet == nil {
+		keySet = NewJWKSKeySet(jwksURL)
+	}
+	return func(c *gin.Context) {
+		bearer := c.GetHeader("Authorization")
+		if bearer == "" {
+			if o.requireAuthHeader {
+				writeJWTError(c, 401, "missing_token", "authorization header is required")
+				return
+			}
+			c.Next()
+			return
+		}
+		token, err := parseWithKeySet([]byte(bearer), issuer, keySet)
+		if err != nil {
+			writeJWTError(c, 401, classifyJWTError(err), err.Error())
+			return
+		}
+		if err := checkAudience(token, o.audiences); err != nil {
+			writeJWTError(c, 401, "invalid_audience", err.Error())
+			return
+		}
+		c.Set("token", token)
+		c.Next()
+	}
+}
+
+// WithKeySet supplies a pre-built KeySet to JWTWithJWKS instead of having
+// it fetch and cache its own from the JWKS URL.
+func WithKeySet(keySet KeySet) JWTOption {
+	return func(o *jwtOptions) {
+		o.keySet = keySet
+	}
+}
+
+// parseWithKeySet verifies a compact JWS against keySet by looking up the
+// key matching its kid header, then parses and validates it as a JWT.
+func parseWithKeySet(data []byte, issuer string, keySet KeySet) (jwt.Token, error) {
+	alg, kid, err := parseJWTHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keySet.LookupKeyID(kid)
+	if !ok {
+		if refreshable, refreshableOK := keySet.(refreshableKeySet); refreshableOK {
+			refreshable.refreshOnUnknownKeyID()
+			key, ok = keySet.LookupKeyID(kid)
+		}
+		if !ok {
+			return nil, ErrJWTUnknownKeyID
+		}
+	}
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, err
+	}
+	return jwt.Parse(data,
+		jwt.WithIssuer(issuer),
+		jwt.WithKey(alg, raw),
+		jwt.WithValidate(true),
+		jwt.WithVerify(true),
+	)
+}
+
+// parseJWTHeader decodes the unverified protected header of a compact JWS
+// to recover the alg and kid needed to select a verification key.
+func parseJWTHeader(data []byte) (jwa.SignatureAlgorithm, string, error) {
+	parts := strings.SplitN(string(data), ".", 3)
+	if len(parts) != 3 {
+		return "", "", ErrJWTMalformed
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", ErrJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return "", "", ErrJWTMalformed
+	}
+	if header.Kid == "" {
+		return "", "", ErrJWTMissingKeyID
+	}
+	switch header.Alg {
+	case "RS256":
+		return jwa.RS256(), header.Kid, nil
+	case "ES256":
+		return jwa.ES256(), header.Kid, nil
+	default:
+		return "", "", ErrJWTUnsupportedAlgorithm
+	}
+}
+
+// jwksKeySet fetches and caches a JWKS document from a URL. It refreshes
+// on a fixed interval, and additionally allows a single out-of-band
+// refresh per minRefreshInterval when a token references a kid it doesn't
+// recognize, so a burst of tokens signed with a just-rotated key doesn't
+// each trigger their own fetch once a refreshed set is cached. Lookups that
+// find the set stale while a fetch is still in flight are not coalesced
+// with it and each start their own fetch.
+type jwksKeySet struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	fetchTimeout       time.Duration
+
+	mu        sync.Mutex
+	set       jwk.Set
+	fetchedAt time.Time
+}
+
+// JWKSKeySetOption configures NewJWKSKeySet.
+type JWKSKeySetOption func(*jwksKeySet)
+
+// WithJWKSHTTPClient sets the HTTP client used to fetch the JWKS document.
+func WithJWKSHTTPClient(client *http.Client) JWKSKeySetOption {
+	return func(k *jwksKeySet) { k.client = client }
+}
+
+// WithJWKSRefreshInterval sets how often the JWKS document is refreshed.
+// It defaults to one hour.
+func WithJWKSRefreshInterval(d time.Duration) JWKSKeySetOption {
+	return func(k *jwksKeySet) { k.refreshInterval = d }
+}
+
+// WithJWKSMinRefreshInterval bounds how often an unknown kid can trigger an
+// out-of-band refresh, protecting the JWKS endpoint from a stampede of
+// requests bearing bogus or stale key IDs. It defaults to five minutes.
+func WithJWKSMinRefreshInterval(d time.Duration) JWKSKeySetOption {
+	return func(k *jwksKeySet) { k.minRefreshInterval = d }
+}
+
+// WithJWKSFetchTimeout bounds how long a single JWKS fetch is allowed to
+// take. It defaults to ten seconds, so a slow or hanging JWKS endpoint
+// can't stall requests waiting on LookupKeyID indefinitely.
+func WithJWKSFetchTimeout(d time.Duration) JWKSKeySetOption {
+	return func(k *jwksKeySet) { k.fetchTimeout = d }
+}
+
+// NewJWKSKeySet creates a KeySet that lazily fetches and caches the JWKS
+// document at url.
+func NewJWKSKeySet(url string, opts ...JWKSKeySetOption) KeySet {
+	k := &jwksKeySet{
+		url:                url,
+		client:             http.DefaultClient,
+		refreshInterval:    time.Hour,
+		minRefreshInterval: 5 * time.Minute,
+		fetchTimeout:       10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
+}
+
+// LookupKeyID implements KeySet.
+func (k *jwksKeySet) LookupKeyID(kid string) (jwk.Key, bool) {
+	k.refresh(false)
+	k.mu.Lock()
+	set := k.set
+	k.mu.Unlock()
+	if set == nil {
+		return nil, false
+	}
+	return set.LookupKeyID(kid)
+}
+
+// refreshOnUnknownKeyID implements refreshableKeySet.
+func (k *jwksKeySet) refreshOnUnknownKeyID() {
+	k.refresh(true)
+}
+
+// refresh fetches the JWKS document if the cached one is older than
+// refreshInterval, or, if force is set (an unknown kid was seen), older
+// than the much shorter minRefreshInterval. A fetch error leaves the
+// existing cached set in place. The fetch itself runs without holding mu,
+// bounded by fetchTimeout, so a slow or hanging JWKS endpoint blocks only
+// the callers that triggered a refresh, not every concurrent LookupKeyID.
+func (k *jwksKeySet) refresh(force bool) {
+	k.mu.Lock()
+	since := time.Since(k.fetchedAt)
+	stale := k.set == nil
+	if !stale {
+		if force {
+			stale = since >= k.minRefreshInterval
+		} else {
+			stale = since >= k.refreshInterval
+		}
+	}
+	k.mu.Unlock()
+	if !stale {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), k.fetchTimeout)
+	defer cancel()
+	set, err := jwk.Fetch(ctx, k.url, jwk.WithHTTPClient(k.client))
+	if err != nil {
+		return
+	}
+
+	k.mu.Lock()
+	k.set = set
+	k.fetchedAt = time.Now()
+	k.mu.Unlock()
+}
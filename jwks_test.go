@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// rsaJWK is the RFC 7517 JSON representation of an RSA public key.
+type rsaJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func toRSAJWK(kid string, pub *rsa.PublicKey) rsaJWK {
+	e := big.NewInt(int64(pub.E)).Bytes()
+	return rsaJWK{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(e),
+	}
+}
+
+// jwksServer serves a JWKS document built from whichever keys are
+// currently registered via set, so a test can simulate key rotation by
+// calling set again between requests.
+type jwksServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	keys []rsaJWK
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		keys := append([]rsaJWK{}, s.keys...)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(keys ...rsaJWK) {
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+// signRS256 hand-builds and signs a compact JWS independently of the jwx
+// library under test, so these tests don't just check our signer against
+// our own verifier.
+func signRS256(t *testing.T, kid string, claims map[string]interface{}, priv *rsa.PrivateKey) string {
+	t.Helper()
+	header := map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}
+	headerJSON, err := json.Marshal(header)
+	require.Nil(t, err)
+	claimsJSON, err := json.Marshal(claims)
+	require.Nil(t, err)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	require.Nil(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newJWKSTestServer(t *testing.T, handler gin.HandlerFunc) *httptest.Server {
+	t.Helper()
+	g := gin.New()
+	g.GET("/protected", handler, func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	server := httptest.NewServer(g)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_that_JWTWithJWKS_verifies_a_token_against_a_fetched_key(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	jwks := newJWKSServer(t)
+	jwks.set(toRSAJWK("key-1", &priv.PublicKey))
+
+	server := newJWKSTestServer(t, JWTWithJWKS("issuer", "audience", jwks.URL))
+
+	token := signRS256(t, "key-1", map[string]interface{}{
+		"iss": "issuer",
+		"aud": []string{"audience"},
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, priv)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func Test_that_JWTWithJWKS_rejects_a_kid_that_is_not_in_the_set(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	jwks := newJWKSServer(t)
+	jwks.set(toRSAJWK("key-1", &priv.PublicKey))
+
+	server := newJWKSTestServer(t, JWTWithJWKS("issuer", "audience", jwks.URL))
+
+	token := signRS256(t, "unknown-kid", map[string]interface{}{
+		"iss": "issuer",
+		"aud": []string{"audience"},
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, other)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func Test_that_JWTWithJWKS_picks_up_a_rotated_key_on_an_unknown_kid(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	jwks := newJWKSServer(t)
+	jwks.set(toRSAJWK("old-key", &oldKey.PublicKey))
+
+	keySet := NewJWKSKeySet(jwks.URL, WithJWKSMinRefreshInterval(0))
+	server := newJWKSTestServer(t, JWTWithJWKS("issuer", "audience", jwks.URL, WithKeySet(keySet)))
+
+	claims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": "issuer",
+			"aud": []string{"audience"},
+			"sub": "alice",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	// Prime the cache against the old key.
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", signRS256(t, "old-key", claims(), oldKey))
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Rotate: the JWKS endpoint now serves a new key, without the cache
+	// having refreshed on its own.
+	jwks.set(toRSAJWK("new-key", &newKey.PublicKey))
+
+	req, _ = http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", signRS256(t, "new-key", claims(), newKey))
+	resp, err = server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func Test_that_jwksKeySet_fetch_timeout_bounds_a_hanging_JWKS_endpoint(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	// server.Close waits for outstanding requests to finish, so block must
+	// be closed (unblocking the hanging handler) before it's called.
+	defer server.Close()
+	defer close(block)
+
+	keySet := NewJWKSKeySet(server.URL, WithJWKSFetchTimeout(50*time.Millisecond))
+
+	done := make(chan struct{})
+	go func() {
+		keySet.LookupKeyID("anything")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("LookupKeyID did not return within its fetch timeout")
+	}
+}
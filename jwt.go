@@ -1,29 +1,78 @@
 package backend
 
 import (
+	"errors"
+	"strings"
+	"unicode"
+
 	"github.com/gin-gonic/gin"
 	"github.com/lestrrat-go/jwx/v3/jwa"
 	"github.com/lestrrat-go/jwx/v3/jwt"
 )
 
-// JWT is a middleware that verifies the JWT in the Authorization header.
-func JWT(issuer, audience, secret string) gin.HandlerFunc {
+// JWTOption configures the JWT and JWTWithJWKS middleware.
+type JWTOption func(*jwtOptions)
+
+// jwtOptions holds the configuration shared by JWT and JWTWithJWKS.
+type jwtOptions struct {
+	audiences         []string
+	requireAuthHeader bool
+	keySet            KeySet
+}
+
+// WithAudiences adds additional audiences that are accepted alongside the
+// one passed directly to JWT/JWTWithJWKS.
+func WithAudiences(audiences ...string) JWTOption {
+	return func(o *jwtOptions) {
+		o.audiences = append(o.audiences, audiences...)
+	}
+}
+
+// WithRequireAuthHeader controls whether a request with no Authorization
+// header is rejected with a 401 instead of being passed through
+// anonymously. It defaults to false, matching JWT's historical behavior.
+func WithRequireAuthHeader(require bool) JWTOption {
+	return func(o *jwtOptions) {
+		o.requireAuthHeader = require
+	}
+}
+
+// newJWTOptions builds the jwtOptions for a middleware configured with the
+// given primary audience and options.
+func newJWTOptions(audience string, opts []JWTOption) *jwtOptions {
+	o := &jwtOptions{audiences: []string{audience}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// JWT is a middleware that verifies the JWT in the Authorization header
+// using a static HS256 secret.
+func JWT(issuer, audience, secret string, opts ...JWTOption) gin.HandlerFunc {
+	o := newJWTOptions(audience, opts)
 	return func(c *gin.Context) {
 		bearer := c.GetHeader("Authorization")
 		if bearer == "" {
+			if o.requireAuthHeader {
+				writeJWTError(c, 401, "missing_token", "authorization header is required")
+				return
+			}
 			c.Next()
 			return
 		}
 		token, err := jwt.Parse([]byte(bearer),
 			jwt.WithIssuer(issuer),
-			jwt.WithAudience(audience),
 			jwt.WithKey(jwa.HS256(), []byte(secret)),
 			jwt.WithValidate(true),
 			jwt.WithVerify(true),
 		)
 		if err != nil {
-			c.JSON(401, gin.H{"error": "invalid token"})
-			c.Abort()
+			writeJWTError(c, 401, classifyJWTError(err), err.Error())
+			return
+		}
+		if err := checkAudience(token, o.audiences); err != nil {
+			writeJWTError(c, 401, "invalid_audience", err.Error())
 			return
 		}
 		c.Set("token", token)
@@ -31,6 +80,71 @@ func JWT(issuer, audience, secret string) gin.HandlerFunc {
 	}
 }
 
+// writeJWTError writes a structured JSON error body and aborts the request.
+func writeJWTError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{"error": code, "message": message})
+	c.Abort()
+}
+
+// checkAudience returns ErrJWTAudienceMismatch unless token's "aud" claim
+// contains at least one of the accepted audiences, or ErrJWTMissingAudience
+// if the token has no "aud" claim.
+func checkAudience(token jwt.Token, accepted []string) error {
+	if len(accepted) == 0 {
+		return nil
+	}
+	var aud []string
+	if err := token.Get("aud", &aud); err != nil {
+		return ErrJWTMissingAudience
+	}
+	for _, got := range aud {
+		for _, want := range accepted {
+			if got == want {
+				return nil
+			}
+		}
+	}
+	return ErrJWTAudienceMismatch
+}
+
+// classifyJWTError maps a token parsing/validation error to a short,
+// stable code safe to return to API clients in place of the underlying
+// library error text.
+func classifyJWTError(err error) string {
+	switch {
+	case errors.Is(err, ErrJWTUnknownKeyID):
+		return "unknown_key_id"
+	case errors.Is(err, ErrJWTMissingKeyID):
+		return "missing_key_id"
+	case errors.Is(err, ErrJWTUnsupportedAlgorithm):
+		return "unsupported_algorithm"
+	case errors.Is(err, ErrJWTMalformed):
+		return "malformed_token"
+	}
+	msg := err.Error()
+	switch {
+	case containsWord(msg, "exp"):
+		return "token_expired"
+	case containsWord(msg, "iss"):
+		return "invalid_issuer"
+	case containsWord(msg, "signature") || containsWord(msg, "verify"):
+		return "invalid_signature"
+	default:
+		return "invalid_token"
+	}
+}
+
+// containsWord reports whether word occurs in s as a standalone,
+// letters-only token, so e.g. "iss" doesn't match inside "missing".
+func containsWord(s, word string) bool {
+	for _, token := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if strings.EqualFold(token, word) {
+			return true
+		}
+	}
+	return false
+}
+
 // RequirePermissionsClaim is a middleware that requires the token to have the given
 // permissions in the claim.
 func RequirePermissionsClaim(claim string, assertion PermissionsAssertion) gin.HandlerFunc {
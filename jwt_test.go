@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+// signHS256 mints a compact JWS the same way mintJWT does, for use as a
+// test fixture.
+func signHS256(t *testing.T, issuer, audience, subject, secret string, ttl time.Duration) string {
+	t.Helper()
+	token, err := jwt.NewBuilder().
+		Issuer(issuer).
+		Audience([]string{audience}).
+		Subject(subject).
+		IssuedAt(time.Now()).
+		Expiration(time.Now().Add(ttl)).
+		Build()
+	require.Nil(t, err)
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256(), []byte(secret)))
+	require.Nil(t, err)
+	return string(signed)
+}
+
+func newJWTTestServer(handler gin.HandlerFunc) *httptest.Server {
+	g := gin.New()
+	g.GET("/protected", handler, func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return httptest.NewServer(g)
+}
+
+func Test_that_JWT_allows_a_valid_token_through(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret"))
+	defer server.Close()
+
+	token := signHS256(t, "issuer", "audience", "alice", "secret", time.Hour)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func Test_that_JWT_passes_through_anonymously_when_no_header_is_present(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret"))
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/protected")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func Test_that_WithRequireAuthHeader_rejects_a_missing_header(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret", WithRequireAuthHeader(true)))
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/protected")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func Test_that_JWT_rejects_an_expired_token(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret"))
+	defer server.Close()
+
+	token := signHS256(t, "issuer", "audience", "alice", "secret", -time.Hour)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func Test_that_JWT_rejects_a_token_signed_with_the_wrong_secret(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret"))
+	defer server.Close()
+
+	token := signHS256(t, "issuer", "audience", "alice", "wrong-secret", time.Hour)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func Test_that_JWT_rejects_a_token_with_the_wrong_issuer(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret"))
+	defer server.Close()
+
+	token := signHS256(t, "someone-else", "audience", "alice", "secret", time.Hour)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func Test_that_JWT_rejects_a_token_whose_audience_does_not_match(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret"))
+	defer server.Close()
+
+	token := signHS256(t, "issuer", "someone-elses-audience", "alice", "secret", time.Hour)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func Test_that_WithAudiences_accepts_any_of_the_configured_audiences(t *testing.T) {
+	server := newJWTTestServer(JWT("issuer", "audience", "secret", WithAudiences("other-audience")))
+	defer server.Close()
+
+	token := signHS256(t, "issuer", "other-audience", "alice", "secret", time.Hour)
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	req.Header.Set("Authorization", token)
+	resp, err := server.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// Test_that_classifyJWTError_does_not_mistake_missing_for_invalid_issuer is
+// a regression test: "missing" contains "iss" as a substring, which a
+// naive strings.Contains(msg, "iss") check would mismatch against the
+// "invalid_issuer" code.
+func Test_that_classifyJWTError_does_not_mistake_missing_for_invalid_issuer(t *testing.T) {
+	code := classifyJWTError(errors.New("token is missing required claim sub"))
+	require.Equal(t, "invalid_token", code)
+}
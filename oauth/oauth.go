@@ -0,0 +1,180 @@
+// Package oauth implements the upstream side of an auth-code + PKCE
+// OAuth2/OIDC login: building the authorization URL, exchanging the code,
+// and fetching userinfo. It knows nothing about gin, the Injector, or how
+// the resulting identity becomes a local session -- see
+// backend.Router.MountOAuth for how it's wired into the rest of the
+// framework.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Provider describes an upstream OAuth2/OIDC identity provider.
+type Provider struct {
+	// ClientID is the OAuth2 client ID registered with the provider.
+	ClientID string
+	// ClientSecret is the OAuth2 client secret registered with the provider.
+	ClientSecret string
+	// AuthURL is the provider's authorization endpoint.
+	AuthURL string
+	// TokenURL is the provider's token endpoint.
+	TokenURL string
+	// UserinfoURL is the provider's userinfo endpoint. Userinfo returns an
+	// error if it is empty; claims are not read from the ID token instead.
+	UserinfoURL string
+	// Scopes are the OAuth2 scopes requested during authorization.
+	Scopes []string
+}
+
+// Providers is a registry of upstream identity providers, keyed by the
+// name used in the /authorize/:provider and /callback/:provider routes.
+type Providers map[string]Provider
+
+// Google returns a Provider preconfigured for Google's OAuth2/OIDC
+// endpoints, using the given client ID, client secret and scopes.
+func Google(clientID, clientSecret string, scopes ...string) Provider {
+	return Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		UserinfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:       scopes,
+	}
+}
+
+// GitHub returns a Provider preconfigured for GitHub's OAuth2 endpoints,
+// using the given client ID, client secret and scopes.
+func GitHub(clientID, clientSecret string, scopes ...string) Provider {
+	return Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		UserinfoURL:  "https://api.github.com/user",
+		Scopes:       scopes,
+	}
+}
+
+// AuthorizationURL builds the upstream authorization URL for the
+// auth-code + PKCE flow, to which the user agent should be redirected.
+func (p Provider) AuthorizationURL(redirectURI, state, codeChallenge string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.ClientID)
+	v.Set("redirect_uri", redirectURI)
+	v.Set("scope", strings.Join(p.Scopes, " "))
+	v.Set("state", state)
+	v.Set("code_challenge", codeChallenge)
+	v.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + v.Encode()
+}
+
+// TokenResponse is the subset of a provider's token-endpoint response that
+// Exchange cares about.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// Exchange trades an authorization code, along with its PKCE verifier, for
+// tokens at the provider's token endpoint. client defaults to
+// http.DefaultClient if nil.
+func (p Provider) Exchange(ctx context.Context, client *http.Client, code, redirectURI, codeVerifier string) (*TokenResponse, error) {
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", redirectURI)
+	v.Set("client_id", p.ClientID)
+	v.Set("client_secret", p.ClientSecret)
+	v.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth: token endpoint returned %d", resp.StatusCode)
+	}
+	var tr TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, err
+	}
+	return &tr, nil
+}
+
+// Userinfo fetches the provider's userinfo endpoint using accessToken.
+// client defaults to http.DefaultClient if nil.
+func (p Provider) Userinfo(ctx context.Context, client *http.Client, accessToken string) (map[string]interface{}, error) {
+	if p.UserinfoURL == "" {
+		return nil, errors.New("oauth: provider has no userinfo endpoint")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserinfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth: userinfo endpoint returned %d", resp.StatusCode)
+	}
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// NewPKCE generates a random code verifier and its S256 code challenge for
+// the auth-code + PKCE flow.
+func NewPKCE() (verifier, challenge string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	return verifier, challenge, nil
+}
+
+// NewStateValue generates a random CSRF state value for the authorization
+// request.
+func NewStateValue() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
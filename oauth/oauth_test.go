@@ -0,0 +1,36 @@
+package oauth
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_that_NewPKCE_returns_a_verifier_and_its_S256_challenge(t *testing.T) {
+	verifier, challenge, err := NewPKCE()
+	require.Nil(t, err)
+	require.True(t, len(verifier) > 0)
+	require.True(t, len(challenge) > 0)
+	require.True(t, verifier != challenge)
+}
+
+func Test_that_NewStateValue_returns_distinct_values(t *testing.T) {
+	a, err := NewStateValue()
+	require.Nil(t, err)
+	b, err := NewStateValue()
+	require.Nil(t, err)
+	require.True(t, a != b)
+}
+
+func Test_that_Provider_AuthorizationURL_includes_PKCE_and_state_params(t *testing.T) {
+	p := Provider{ClientID: "client", AuthURL: "https://example.com/authorize", Scopes: []string{"openid", "email"}}
+	u := p.AuthorizationURL("https://app.example.com/callback", "the-state", "the-challenge")
+
+	parsed, err := url.Parse(u)
+	require.Nil(t, err)
+	q := parsed.Query()
+	require.Equal(t, "the-state", q.Get("state"))
+	require.Equal(t, "the-challenge", q.Get("code_challenge"))
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+}
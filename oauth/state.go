@@ -0,0 +1,133 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// State is the per-authorization-attempt data a StateStore round-trips
+// from the /authorize request to the /callback request: the CSRF state
+// value, the PKCE code verifier, and where to send the user afterwards.
+type State struct {
+	Value        string
+	CodeVerifier string
+	ReturnTo     string
+}
+
+// StateStore persists a State across the redirect to the upstream
+// provider and back. The default, CookieStateStore, signs and stores it
+// in a cookie so no server-side storage is required.
+type StateStore interface {
+	// Save persists state for the current request.
+	Save(w http.ResponseWriter, r *http.Request, state State) error
+	// Load retrieves and clears the State saved by Save for this request.
+	// It returns ErrStateNotFound if the request carries no valid state.
+	Load(w http.ResponseWriter, r *http.Request) (State, error)
+}
+
+// ErrStateNotFound is returned by StateStore.Load when the callback
+// request carries no (or an invalid/expired) state.
+var ErrStateNotFound = errors.New("oauth: state not found")
+
+// CookieStateStore is the default StateStore. It HMAC-signs the State and
+// stores it in a short-lived cookie, so the callback can be verified
+// without server-side session storage.
+type CookieStateStore struct {
+	// CookieName is the name of the cookie used to carry the signed
+	// state. Defaults to "oauth_state" if empty.
+	CookieName string
+	// Secret signs the cookie contents so it can't be forged or tampered
+	// with by the client.
+	Secret []byte
+	// MaxAge bounds how long the user has to complete the redirect back
+	// from the provider. Defaults to 10 minutes if zero.
+	MaxAge time.Duration
+}
+
+func (s *CookieStateStore) cookieName() string {
+	if s.CookieName != "" {
+		return s.CookieName
+	}
+	return "oauth_state"
+}
+
+func (s *CookieStateStore) maxAge() time.Duration {
+	if s.MaxAge != 0 {
+		return s.MaxAge
+	}
+	return 10 * time.Minute
+}
+
+// Save implements StateStore.
+func (s *CookieStateStore) Save(w http.ResponseWriter, r *http.Request, state State) error {
+	encoded, err := s.encode(state)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName(),
+		Value:    encoded,
+		Path:     "/",
+		MaxAge:   int(s.maxAge().Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Load implements StateStore.
+func (s *CookieStateStore) Load(w http.ResponseWriter, r *http.Request) (State, error) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName(),
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	cookie, err := r.Cookie(s.cookieName())
+	if err != nil {
+		return State{}, ErrStateNotFound
+	}
+	return s.decode(cookie.Value)
+}
+
+// encode serializes and HMAC-signs state as "payload.signature", both
+// base64url-encoded.
+func (s *CookieStateStore) encode(state State) (string, error) {
+	payload := strings.Join([]string{state.Value, state.CodeVerifier, state.ReturnTo}, "\x00")
+	encodedPayload := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(encodedPayload))
+	signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return encodedPayload + "." + signature, nil
+}
+
+// decode verifies and deserializes a value produced by encode.
+func (s *CookieStateStore) decode(value string) (State, error) {
+	parts := strings.SplitN(value, ".", 2)
+	if len(parts) != 2 {
+		return State{}, ErrStateNotFound
+	}
+	encodedPayload, signature := parts[0], parts[1]
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(encodedPayload))
+	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return State{}, ErrStateNotFound
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return State{}, ErrStateNotFound
+	}
+	fields := strings.SplitN(string(payload), "\x00", 3)
+	if len(fields) != 3 {
+		return State{}, ErrStateNotFound
+	}
+	return State{Value: fields[0], CodeVerifier: fields[1], ReturnTo: fields[2]}, nil
+}
@@ -0,0 +1,52 @@
+package oauth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_that_CookieStateStore_round_trips_state(t *testing.T) {
+	store := &CookieStateStore{Secret: []byte("secret")}
+	saveReq := httptest.NewRequest("GET", "/authorize/google", nil)
+	rec := httptest.NewRecorder()
+	err := store.Save(rec, saveReq, State{Value: "abc", CodeVerifier: "verifier", ReturnTo: "/home"})
+	require.Nil(t, err)
+
+	loadReq := httptest.NewRequest("GET", "/callback/google", nil)
+	for _, c := range rec.Result().Cookies() {
+		loadReq.AddCookie(c)
+	}
+	loadRec := httptest.NewRecorder()
+	state, err := store.Load(loadRec, loadReq)
+	require.Nil(t, err)
+	require.Equal(t, "abc", state.Value)
+	require.Equal(t, "verifier", state.CodeVerifier)
+	require.Equal(t, "/home", state.ReturnTo)
+}
+
+func Test_that_CookieStateStore_Load_returns_error_when_no_cookie_present(t *testing.T) {
+	store := &CookieStateStore{Secret: []byte("secret")}
+	req := httptest.NewRequest("GET", "/callback/google", nil)
+	rec := httptest.NewRecorder()
+	_, err := store.Load(rec, req)
+	require.Equal(t, ErrStateNotFound, err)
+}
+
+func Test_that_CookieStateStore_Load_rejects_a_tampered_cookie(t *testing.T) {
+	saveStore := &CookieStateStore{Secret: []byte("secret")}
+	saveReq := httptest.NewRequest("GET", "/authorize/google", nil)
+	rec := httptest.NewRecorder()
+	err := saveStore.Save(rec, saveReq, State{Value: "abc", CodeVerifier: "verifier", ReturnTo: "/home"})
+	require.Nil(t, err)
+
+	loadReq := httptest.NewRequest("GET", "/callback/google", nil)
+	for _, c := range rec.Result().Cookies() {
+		loadReq.AddCookie(c)
+	}
+	loadRec := httptest.NewRecorder()
+	differentSecretStore := &CookieStateStore{Secret: []byte("different")}
+	_, err = differentSecretStore.Load(loadRec, loadReq)
+	require.Equal(t, ErrStateNotFound, err)
+}
@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/smxlong/backend/oauth"
+)
+
+// OAuthConfig configures MountOAuth.
+type OAuthConfig struct {
+	// Providers is the registry of upstream identity providers, keyed by
+	// the name used in the /authorize/:provider and /callback/:provider
+	// routes.
+	Providers oauth.Providers
+	// RedirectBaseURL is this service's externally reachable base URL,
+	// used to build each provider's redirect_uri as
+	// RedirectBaseURL+prefix+"/callback/"+name.
+	RedirectBaseURL string
+	// StateStore persists state across the redirect to the provider and
+	// back. Defaults to an oauth.CookieStateStore signed with Secret.
+	StateStore oauth.StateStore
+	// Issuer, Audience and Secret mint the local JWT issued after a
+	// successful login, and must match the values passed to JWT so that
+	// RequirePermissions and friends keep validating it unchanged.
+	Issuer   string
+	Audience string
+	Secret   string
+	// SessionDuration bounds how long the minted JWT is valid. Defaults
+	// to one hour if zero.
+	SessionDuration time.Duration
+	// Claims derives the claims to embed in the minted JWT (e.g.
+	// "permissions") from the provider's userinfo response. Optional.
+	Claims func(providerName string, userinfo map[string]interface{}) (map[string]interface{}, error)
+	// HTTPClient is used for the token exchange and userinfo requests.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// OnLogin, if set, is called after userinfo is fetched and before the
+	// local JWT is minted, so the caller can create/update a user record
+	// or reject the login outright.
+	OnLogin func(c *gin.Context, providerName string, userinfo map[string]interface{}) error
+}
+
+// MountOAuth installs /authorize/:provider, /callback/:provider and
+// /logout endpoints under prefix for each provider in cfg.Providers. It
+// performs the auth-code + PKCE flow against the provider and, on
+// success, mints a local JWT using the same issuer/audience/secret that
+// JWT validates, so RequirePermissions and friends keep working
+// unchanged downstream.
+func (r *Router) MountOAuth(prefix string, cfg OAuthConfig) {
+	if cfg.StateStore == nil {
+		cfg.StateStore = &oauth.CookieStateStore{Secret: []byte(cfg.Secret)}
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.SessionDuration == 0 {
+		cfg.SessionDuration = time.Hour
+	}
+
+	r.GET(prefix+"/authorize/:provider", func(c *gin.Context) {
+		name := c.Param("provider")
+		p, ok := cfg.Providers[name]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown_provider"})
+			return
+		}
+		verifier, challenge, err := oauth.NewPKCE()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "pkce_failed"})
+			return
+		}
+		state, err := oauth.NewStateValue()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "state_failed"})
+			return
+		}
+		if err := cfg.StateStore.Save(c.Writer, c.Request, oauth.State{
+			Value:        state,
+			CodeVerifier: verifier,
+			ReturnTo:     c.Query("return_to"),
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "state_save_failed"})
+			return
+		}
+		c.Redirect(http.StatusFound, p.AuthorizationURL(cfg.redirectURI(prefix, name), state, challenge))
+	})
+
+	r.GET(prefix+"/callback/:provider", func(c *gin.Context) {
+		name := c.Param("provider")
+		p, ok := cfg.Providers[name]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown_provider"})
+			return
+		}
+		st, err := cfg.StateStore.Load(c.Writer, c.Request)
+		if err != nil || st.Value != c.Query("state") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_state"})
+			return
+		}
+		code := c.Query("code")
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing_code"})
+			return
+		}
+		tok, err := p.Exchange(c.Request.Context(), cfg.HTTPClient, code, cfg.redirectURI(prefix, name), st.CodeVerifier)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "exchange_failed"})
+			return
+		}
+		userinfo, err := p.Userinfo(c.Request.Context(), cfg.HTTPClient, tok.AccessToken)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "userinfo_failed"})
+			return
+		}
+		if cfg.OnLogin != nil {
+			if err := cfg.OnLogin(c, name, userinfo); err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "login_rejected"})
+				return
+			}
+		}
+		claims := map[string]interface{}{}
+		if cfg.Claims != nil {
+			claims, err = cfg.Claims(name, userinfo)
+			if err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "claims_failed"})
+				return
+			}
+		}
+		subject := subjectOf(userinfo)
+		if subject == "" {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "subject_unresolved"})
+			return
+		}
+		signed, err := mintJWT(cfg.Issuer, cfg.Audience, cfg.Secret, subject, cfg.SessionDuration, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "mint_failed"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"token": signed, "return_to": st.ReturnTo})
+	})
+
+	r.POST(prefix+"/logout", func(c *gin.Context) {
+		// Load clears the state cookie as a side effect; a missing or
+		// already-consumed cookie isn't an error here.
+		_, _ = cfg.StateStore.Load(c.Writer, c.Request)
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// redirectURI is the redirect_uri this service presents to provider name,
+// which must also be registered with the provider out of band.
+func (cfg OAuthConfig) redirectURI(prefix, name string) string {
+	return cfg.RedirectBaseURL + prefix + "/callback/" + name
+}
+
+// subjectOf derives a JWT subject from a provider's userinfo response.
+func subjectOf(userinfo map[string]interface{}) string {
+	if sub, ok := userinfo["sub"].(string); ok {
+		return sub
+	}
+	if id, ok := userinfo["id"].(float64); ok {
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return ""
+}
+
+// mintJWT builds and signs a JWT with the given issuer/audience/secret
+// using the same HS256 scheme JWT validates, so it can be handed straight
+// back to JWT-protected endpoints.
+func mintJWT(issuer, audience, secret, subject string, ttl time.Duration, claims map[string]interface{}) (string, error) {
+	builder := jwt.NewBuilder().
+		Issuer(issuer).
+		Audience([]string{audience}).
+		Subject(subject).
+		IssuedAt(time.Now()).
+		Expiration(time.Now().Add(ttl))
+	for k, v := range claims {
+		builder = builder.Claim(k, v)
+	}
+	token, err := builder.Build()
+	if err != nil {
+		return "", err
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256(), []byte(secret)))
+	if err != nil {
+		return "", err
+	}
+	return string(signed), nil
+}
@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/smxlong/backend/oauth"
+	"github.com/stretchr/testify/require"
+)
+
+// newOAuthTestRouter builds a Router with MountOAuth installed under
+// "/oauth" for cfg.
+func newOAuthTestRouter(t *testing.T, cfg OAuthConfig) *httptest.Server {
+	t.Helper()
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+	r.MountOAuth("/oauth", cfg)
+	server := httptest.NewServer(g)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newStubProviderServer fakes a provider's token and userinfo endpoints,
+// returning accessToken's userinfo document for any bearer token equal to
+// accessToken.
+func newStubProviderServer(t *testing.T, userinfo map[string]interface{}) (*httptest.Server, string) {
+	t.Helper()
+	const accessToken = "stub-access-token"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer"}`, accessToken)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(userinfo)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, accessToken
+}
+
+func Test_that_authorize_redirects_to_the_provider_and_rejects_an_unknown_one(t *testing.T) {
+	provider, _ := newStubProviderServer(t, map[string]interface{}{"sub": "alice"})
+	server := newOAuthTestRouter(t, OAuthConfig{
+		Providers: oauth.Providers{
+			"stub": {
+				ClientID:    "client-id",
+				AuthURL:     provider.URL + "/authorize",
+				TokenURL:    provider.URL + "/token",
+				UserinfoURL: provider.URL + "/userinfo",
+				Scopes:      []string{"openid"},
+			},
+		},
+		RedirectBaseURL: "https://app.example/",
+		Issuer:          "issuer",
+		Audience:        "audience",
+		Secret:          "secret",
+	})
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(server.URL + "/oauth/authorize/stub")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.Nil(t, err)
+	require.Equal(t, provider.URL+"/authorize", loc.Scheme+"://"+loc.Host+loc.Path)
+
+	resp, err = client.Get(server.URL + "/oauth/authorize/unknown")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// callbackFlow drives /authorize then /callback against server for
+// provider "stub", reusing the cookie jar so state round-trips, and
+// returns the /callback response.
+func callbackFlow(t *testing.T, server *httptest.Server) *http.Response {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	require.Nil(t, err)
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(server.URL + "/oauth/authorize/stub")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.Nil(t, err)
+	state := loc.Query().Get("state")
+
+	callbackURL := server.URL + "/oauth/callback/stub?code=auth-code&state=" + state
+	resp, err = client.Get(callbackURL)
+	require.Nil(t, err)
+	return resp
+}
+
+func Test_that_callback_mints_a_JWT_for_a_successful_login(t *testing.T) {
+	provider, _ := newStubProviderServer(t, map[string]interface{}{"sub": "alice"})
+	server := newOAuthTestRouter(t, OAuthConfig{
+		Providers: oauth.Providers{
+			"stub": {
+				ClientID:    "client-id",
+				AuthURL:     provider.URL + "/authorize",
+				TokenURL:    provider.URL + "/token",
+				UserinfoURL: provider.URL + "/userinfo",
+				Scopes:      []string{"openid"},
+			},
+		},
+		RedirectBaseURL: "https://app.example/",
+		Issuer:          "issuer",
+		Audience:        "audience",
+		Secret:          "secret",
+	})
+
+	resp := callbackFlow(t, server)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.NotEmpty(t, body.Token)
+
+	token, err := jwt.Parse([]byte(body.Token),
+		jwt.WithIssuer("issuer"),
+		jwt.WithKey(jwa.HS256(), []byte("secret")),
+		jwt.WithValidate(true),
+		jwt.WithVerify(true),
+	)
+	require.Nil(t, err)
+	var sub string
+	require.Nil(t, token.Get("sub", &sub))
+	require.Equal(t, "alice", sub)
+}
+
+// Test_that_callback_fails_closed_when_the_subject_cannot_be_resolved is a
+// regression test: a provider whose userinfo has neither a string "sub"
+// nor a numeric "id" must not mint a token for a blank subject.
+func Test_that_callback_fails_closed_when_the_subject_cannot_be_resolved(t *testing.T) {
+	provider, _ := newStubProviderServer(t, map[string]interface{}{"email": "alice@example.com"})
+	server := newOAuthTestRouter(t, OAuthConfig{
+		Providers: oauth.Providers{
+			"stub": {
+				ClientID:    "client-id",
+				AuthURL:     provider.URL + "/authorize",
+				TokenURL:    provider.URL + "/token",
+				UserinfoURL: provider.URL + "/userinfo",
+				Scopes:      []string{"openid"},
+			},
+		},
+		RedirectBaseURL: "https://app.example/",
+		Issuer:          "issuer",
+		Audience:        "audience",
+		Secret:          "secret",
+	})
+
+	resp := callbackFlow(t, server)
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
+
+func Test_that_logout_clears_the_state_cookie(t *testing.T) {
+	server := newOAuthTestRouter(t, OAuthConfig{
+		Providers:       oauth.Providers{},
+		RedirectBaseURL: "https://app.example/",
+		Issuer:          "issuer",
+		Audience:        "audience",
+		Secret:          "secret",
+	})
+
+	resp, err := http.Post(server.URL+"/oauth/logout", "", nil)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	var cleared *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "oauth_state" {
+			cleared = c
+		}
+	}
+	require.NotNil(t, cleared)
+	require.True(t, cleared.MaxAge < 0)
+}
+
+func Test_that_subjectOf_prefers_a_string_sub_then_falls_back_to_a_numeric_id(t *testing.T) {
+	require.Equal(t, "alice", subjectOf(map[string]interface{}{"sub": "alice"}))
+	require.Equal(t, "42", subjectOf(map[string]interface{}{"id": float64(42)}))
+	require.Equal(t, "", subjectOf(map[string]interface{}{"email": "alice@example.com"}))
+}
+
+func Test_that_mintJWT_signs_a_token_with_the_given_claims(t *testing.T) {
+	signed, err := mintJWT("issuer", "audience", "secret", "alice", 0, map[string]interface{}{"permissions": []string{"read"}})
+	require.Nil(t, err)
+	require.NotEmpty(t, signed)
+
+	token, err := jwt.Parse([]byte(signed),
+		jwt.WithIssuer("issuer"),
+		jwt.WithKey(jwa.HS256(), []byte("secret")),
+		jwt.WithValidate(false),
+		jwt.WithVerify(true),
+	)
+	require.Nil(t, err)
+	var permissions []string
+	require.Nil(t, token.Get("permissions", &permissions))
+	require.Equal(t, []string{"read"}, permissions)
+}
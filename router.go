@@ -1,18 +1,18 @@
 package backend
 
 import (
-	"context"
 	"net/http"
 	"reflect"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
-	"github.com/smxlong/kit/webserver"
 )
 
 // Router routes requests to endpoints
 type Router struct {
-	g  *gin.Engine
-	di *Injector
+	g     *gin.Engine
+	di    *Injector
+	ready atomic.Bool
 }
 
 // NewRouter creates a new Router
@@ -27,70 +27,57 @@ func NewRouter(di *Injector) (*Router, error) {
 	}, nil
 }
 
+// Scope creates a per-request child injector for c, populated with
+// *gin.Context, the http.ResponseWriter, the *http.Request, and the
+// request's URL/path params. Handlers resolved through the returned
+// injector can depend on these directly, and can register their own
+// request-scoped overrides (e.g. a *sql.Tx) without leaking them back into
+// the parent injector.
+func (i *Injector) Scope(c *gin.Context) *Injector {
+	child := i.NewChild()
+	child.AddSingleton(c)
+	child.AddSingletonAs(c.Writer, (*http.ResponseWriter)(nil))
+	child.AddSingleton(c.Request)
+	child.AddSingleton(c.Params)
+	return child
+}
+
 // GET routes a GET request to the given path
 func (r *Router) GET(path string, handler interface{}) {
-	r.g.GET(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.GET(path, r.wrap(handler))
 }
 
 // POST routes a POST request to the given path
 func (r *Router) POST(path string, handler interface{}) {
-	r.g.POST(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.POST(path, r.wrap(handler))
 }
 
 // PUT routes a PUT request to the given path
 func (r *Router) PUT(path string, handler interface{}) {
-	r.g.PUT(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.PUT(path, r.wrap(handler))
 }
 
 // DELETE routes a DELETE request to the given path
 func (r *Router) DELETE(path string, handler interface{}) {
-	r.g.DELETE(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.DELETE(path, r.wrap(handler))
 }
 
 // PATCH routes a PATCH request to the given path
 func (r *Router) PATCH(path string, handler interface{}) {
-	r.g.PATCH(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.PATCH(path, r.wrap(handler))
 }
 
 // OPTIONS routes an OPTIONS request to the given path
 func (r *Router) OPTIONS(path string, handler interface{}) {
-	r.g.OPTIONS(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.OPTIONS(path, r.wrap(handler))
 }
 
 // HEAD routes a HEAD request to the given path
 func (r *Router) HEAD(path string, handler interface{}) {
-	r.g.HEAD(path, func(c *gin.Context) {
-		r.di.Invoke(handler, c)
-	})
+	r.g.HEAD(path, r.wrap(handler))
 }
 
 // ServeHTTP implements the http.Handler interface
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.g.ServeHTTP(w, req)
 }
-
-// RunContext runs the router on the given address until the context is done
-func (r *Router) RunContext(ctx context.Context, addr string) error {
-	return webserver.ListenAndServe(ctx, &http.Server{
-		Addr:    addr,
-		Handler: r,
-	})
-}
-
-// Run runs the router on the given address. Use RunContext if you need to shut
-// down the server gracefully.
-func (r *Router) Run(addr string) error {
-	return r.RunContext(context.Background(), addr)
-}
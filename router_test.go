@@ -94,6 +94,47 @@ func Test_that_Router_can_dispatch_requests_with_dependencies(t *testing.T) {
 	}
 }
 
+func Test_that_Scope_injects_the_gin_Context_and_falls_back_to_the_parent(t *testing.T) {
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	di.AddSingleton(&intYielder{42})
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+
+	r.GET("/scoped/:name", func(c *gin.Context, i *intYielder) {
+		c.String(200, "%s:%d", c.Param("name"), i.Get())
+	})
+
+	server := httptest.NewServer(g)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/scoped/alice")
+	require.Nil(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, "alice:42", string(body))
+}
+
+func Test_that_Scope_injects_the_http_ResponseWriter_by_interface(t *testing.T) {
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+
+	r.GET("/writer", func(c *gin.Context, w http.ResponseWriter) {
+		w.WriteHeader(201)
+	})
+
+	server := httptest.NewServer(g)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/writer")
+	require.Nil(t, err)
+	require.Equal(t, 201, resp.StatusCode)
+}
+
 func Test_that_Router_implements_ServeHTTP(t *testing.T) {
 	g := gin.New()
 	di := NewInjector()
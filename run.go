@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// RunOption configures RunContext, RunTLS and RunAutoTLS.
+type RunOption func(*runOptions)
+
+// runOptions holds the configuration shared by RunContext, RunTLS and
+// RunAutoTLS.
+type runOptions struct {
+	drainTimeout  time.Duration
+	readinessPath string
+	signals       []os.Signal
+}
+
+// WithDrainTimeout bounds how long a graceful shutdown waits for in-flight
+// requests to finish before the listener is force-closed. Zero, the
+// default, waits indefinitely.
+func WithDrainTimeout(d time.Duration) RunOption {
+	return func(o *runOptions) {
+		o.drainTimeout = d
+	}
+}
+
+// WithReadinessPath mounts a GET endpoint that answers 200 while the
+// server is accepting requests and 503 once a graceful shutdown has
+// begun. Empty, the default, mounts nothing.
+func WithReadinessPath(path string) RunOption {
+	return func(o *runOptions) {
+		o.readinessPath = path
+	}
+}
+
+// WithSignals overrides the OS signals that trigger a graceful shutdown
+// alongside ctx.Done(). Defaults to SIGINT and SIGTERM.
+func WithSignals(signals ...os.Signal) RunOption {
+	return func(o *runOptions) {
+		o.signals = signals
+	}
+}
+
+// newRunOptions builds the runOptions for a server configured with the
+// given options.
+func newRunOptions(opts []RunOption) *runOptions {
+	o := &runOptions{signals: []os.Signal{os.Interrupt, syscall.SIGTERM}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// RunContext runs the router on the given address until ctx is done or one
+// of opts' signals is received, then drains in-flight requests before
+// returning. Use RunOption to set a drain timeout, mount a readiness
+// endpoint, or change which signals trigger shutdown.
+func (r *Router) RunContext(ctx context.Context, addr string, opts ...RunOption) error {
+	return r.run(ctx, &http.Server{Addr: addr, Handler: r}, "", "", nil, opts)
+}
+
+// Run runs the router on the given address until SIGINT or SIGTERM is
+// received, then drains in-flight requests. Use RunContext to also stop on
+// context cancellation or to pass RunOptions.
+func (r *Router) Run(addr string) error {
+	return r.RunContext(context.Background(), addr)
+}
+
+// RunTLS is RunContext's HTTPS counterpart, serving with the given
+// certificate and key files.
+func (r *Router) RunTLS(ctx context.Context, addr, certFile, keyFile string, opts ...RunOption) error {
+	return r.run(ctx, &http.Server{Addr: addr, Handler: r}, certFile, keyFile, nil, opts)
+}
+
+// RunAutoTLS is RunContext's counterpart for automatic certificate
+// management via ACME. It serves HTTPS on addr using certificates
+// provisioned into cacheDir for domains. If httpAddr is non-empty, it also
+// serves the ACME http-01 challenge (and nothing else) on httpAddr, which
+// the ACME CA must be able to reach on port 80; that challenge listener is
+// shut down alongside the main server.
+func (r *Router) RunAutoTLS(ctx context.Context, addr, httpAddr, cacheDir string, domains []string, opts ...RunOption) error {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+	}
+	var challengeServer *http.Server
+	if httpAddr != "" {
+		challengeServer = &http.Server{Addr: httpAddr, Handler: m.HTTPHandler(nil)}
+	}
+	return r.run(ctx, &http.Server{Addr: addr, Handler: r, TLSConfig: m.TLSConfig()}, "", "", challengeServer, opts)
+}
+
+// run drives the full RunContext/RunTLS/RunAutoTLS lifecycle: it mounts the
+// readiness endpoint (if any), serves server (and challengeServer, if set)
+// until ctx is done or a shutdown signal arrives, flips the readiness flag
+// to false, then drains in-flight requests via server.Shutdown up to opts'
+// drain timeout before force-closing. If either server fails first, run
+// closes the other and returns that error without draining.
+// challengeServer, if non-nil, is shut down alongside server rather than
+// left running past server's lifetime. server.TLSConfig being set (as
+// RunAutoTLS does) serves TLS using that config; a non-empty certFile
+// serves TLS from the given files; otherwise server serves plain HTTP.
+func (r *Router) run(ctx context.Context, server *http.Server, certFile, keyFile string, challengeServer *http.Server, opts []RunOption) error {
+	o := newRunOptions(opts)
+	if o.readinessPath != "" {
+		r.GET(o.readinessPath, func(c *gin.Context) {
+			if r.ready.Load() {
+				c.Status(http.StatusOK)
+				return
+			}
+			c.Status(http.StatusServiceUnavailable)
+		})
+	}
+
+	shutdownCtx, stop := signal.NotifyContext(ctx, o.signals...)
+	defer stop()
+
+	r.ready.Store(true)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		var err error
+		switch {
+		case server.TLSConfig != nil:
+			err = server.ListenAndServeTLS("", "")
+		case certFile != "":
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		default:
+			err = server.ListenAndServe()
+		}
+		if err == http.ErrServerClosed {
+			err = nil
+		}
+		serveErr <- err
+	}()
+
+	// challengeErr is left nil (and so never selected below) when there's
+	// no challengeServer to run.
+	var challengeErr chan error
+	if challengeServer != nil {
+		challengeErr = make(chan error, 1)
+		go func() {
+			err := challengeServer.ListenAndServe()
+			if err == http.ErrServerClosed {
+				err = nil
+			}
+			challengeErr <- err
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		if challengeServer != nil {
+			challengeServer.Close()
+		}
+		return err
+	case err := <-challengeErr:
+		server.Close()
+		if err == nil {
+			err = <-serveErr
+		}
+		return err
+	case <-shutdownCtx.Done():
+	}
+
+	r.ready.Store(false)
+
+	drainCtx := context.Background()
+	if o.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		drainCtx, cancel = context.WithTimeout(drainCtx, o.drainTimeout)
+		defer cancel()
+	}
+	if err := server.Shutdown(drainCtx); err != nil {
+		server.Close()
+		if challengeServer != nil {
+			challengeServer.Close()
+		}
+		return <-serveErr
+	}
+	if challengeServer != nil {
+		challengeServer.Shutdown(drainCtx)
+	}
+	return <-serveErr
+}
@@ -0,0 +1,196 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert generates a self-signed certificate for 127.0.0.1
+// and writes it and its key as PEM files under t.TempDir(), returning
+// their paths for use with RunTLS.
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600))
+	return certFile, keyFile
+}
+
+// freeAddr returns a loopback address with an OS-assigned free port.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := l.Addr().String()
+	require.Nil(t, l.Close())
+	return addr
+}
+
+// waitForListener polls addr until it accepts connections or t fails.
+func waitForListener(t *testing.T, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("nothing listening on %s", addr)
+}
+
+func Test_that_RunContext_drains_and_returns_when_its_context_is_canceled(t *testing.T) {
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunContext(ctx, addr, WithDrainTimeout(time.Second))
+	}()
+
+	waitForListener(t, addr)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunContext did not return after its context was canceled")
+	}
+}
+
+func Test_that_WithReadinessPath_mounts_an_endpoint_that_reports_readiness(t *testing.T) {
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunContext(ctx, addr, WithReadinessPath("/readyz"))
+	}()
+
+	waitForListener(t, addr)
+
+	resp, err := http.Get("http://" + addr + "/readyz")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func Test_that_RunTLS_serves_over_TLS_and_drains_on_cancellation(t *testing.T) {
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+	r.GET("/hello", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	certFile, keyFile := writeSelfSignedCert(t)
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunTLS(ctx, addr, certFile, keyFile, WithDrainTimeout(time.Second))
+	}()
+
+	waitForListener(t, addr)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get("https://" + addr + "/hello")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunTLS did not return after its context was canceled")
+	}
+}
+
+// Test_that_RunAutoTLS_shuts_down_its_challenge_listener_on_cancellation is
+// a regression test: the ACME http-01 challenge listener used to be
+// started with a bare `go http.ListenAndServe` and never stopped, leaking
+// a listener and goroutine past every RunAutoTLS call.
+func Test_that_RunAutoTLS_shuts_down_its_challenge_listener_on_cancellation(t *testing.T) {
+	g := gin.New()
+	di := NewInjector()
+	di.AddSingleton(g)
+	r, err := NewRouter(di)
+	require.Nil(t, err)
+
+	addr := freeAddr(t)
+	httpAddr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunAutoTLS(ctx, addr, httpAddr, t.TempDir(), []string{"example.invalid"}, WithDrainTimeout(time.Second))
+	}()
+
+	waitForListener(t, httpAddr)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunAutoTLS did not return after its context was canceled")
+	}
+
+	conn, err := net.Dial("tcp", httpAddr)
+	if err == nil {
+		conn.Close()
+		t.Fatal("challenge listener is still accepting connections after RunAutoTLS returned")
+	}
+}